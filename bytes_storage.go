@@ -0,0 +1,351 @@
+package loadingcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MarshalFunc serializes a value into bytes to be kept in a
+// BytesStorage arena.
+type MarshalFunc func(value interface{}) ([]byte, error)
+
+// UnmarshalFunc deserializes bytes previously produced by a
+// MarshalFunc back into a value.
+type UnmarshalFunc func(data []byte) (interface{}, error)
+
+// BytesStorageOptions configures a BytesStorage.
+type BytesStorageOptions struct {
+	// ShardCount controls how many independent arenas the storage is
+	// split into. Defaults to 1 if not set.
+	ShardCount int
+
+	// HashCodeFunc produces the uint64 hash code used to pick a shard
+	// and to detect key collisions inside it. Defaults to an FNV-1a
+	// hash of fmt.Sprintf("%v", key) if not set.
+	HashCodeFunc func(key interface{}) uint64
+
+	// Marshal serializes values before they are appended to a shard's
+	// arena. Defaults to gob encoding.
+	Marshal MarshalFunc
+
+	// Unmarshal deserializes values read back from a shard's arena.
+	// Defaults to gob decoding.
+	Unmarshal UnmarshalFunc
+
+	// Verbose, when true, logs every detected key collision through
+	// Logger.
+	Verbose bool
+
+	// Logger receives verbose log lines. Defaults to printing each line
+	// to stdout through fmt.Printf if Verbose is true but Logger is not
+	// set.
+	Logger func(format string, args ...interface{})
+}
+
+// NewBytesStorage creates a Storage backed by byte arenas, inspired by
+// allegro/bigcache: instead of keeping one *cacheEntry per key on the
+// Go heap, entries are serialized into a big []byte per shard, so the
+// garbage collector only ever has to scan a handful of slices no
+// matter how many entries the cache holds.
+//
+// Returns a CacheOptions.NewStorage-compatible factory, since every
+// shard of a sharded cache needs its own independent arena.
+func NewBytesStorage(options BytesStorageOptions) func() Storage {
+	if options.ShardCount <= 0 {
+		options.ShardCount = 1
+	}
+	if options.HashCodeFunc == nil {
+		options.HashCodeFunc = fnv64aHashCode
+	}
+	if options.Marshal == nil {
+		options.Marshal = gobMarshal
+	}
+	if options.Unmarshal == nil {
+		options.Unmarshal = gobUnmarshal
+	}
+	if options.Verbose && options.Logger == nil {
+		options.Logger = func(format string, args ...interface{}) {
+			fmt.Printf(format+"\n", args...)
+		}
+	}
+	return func() Storage {
+		b := &bytesStorage{options: options}
+		b.shards = make([]*byteShard, options.ShardCount)
+		for i := range b.shards {
+			b.shards[i] = &byteShard{offsets: map[uint64]int{}}
+		}
+		return b
+	}
+}
+
+func fnv64aHashCode(key interface{}) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	hash := uint64(offset64)
+	for _, b := range []byte(fmt.Sprintf("%v", key)) {
+		hash ^= uint64(b)
+		hash *= prime64
+	}
+	return hash
+}
+
+func gobMarshal(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, errors.Wrap(err, "gob encode")
+	}
+	return buf.Bytes(), nil
+}
+
+func gobUnmarshal(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+		return nil, errors.Wrap(err, "gob decode")
+	}
+	return value, nil
+}
+
+// byteShard is a single hashedKey -> offset index over an append-only
+// byte arena. Each record in the arena is laid out as:
+//
+//	[recordLen uint32][negative byte][lastRead int64][lastWrite int64][keyLen uint32][key][value]
+//
+// For a negative entry (see CacheOptions.NegativeCacheTTL), value holds
+// the raw bytes of the cached Load error's message instead of a
+// Marshal-ed value, since the concrete error type is generally not
+// round-trippable.
+//
+// Overwriting or deleting a key only repoints/removes its offsets
+// entry; the old record's bytes are left behind in the arena and
+// counted in stale. Once stale bytes make up too much of the arena,
+// compact rewrites it keeping only the records still referenced by
+// offsets, so a hot key updated forever (e.g. by RefreshAfterWrite)
+// does not grow the arena without bound.
+type byteShard struct {
+	mu      sync.Mutex
+	arena   []byte
+	offsets map[uint64]int
+	stale   int
+}
+
+// compactionStaleRatio and compactionMinStale gate how eagerly a shard
+// rewrites its arena: both must hold, so small arenas are not
+// repeatedly compacted for a handful of stale bytes.
+const (
+	compactionStaleRatio = 0.5
+	compactionMinStale   = 4096
+)
+
+// maybeCompact rewrites the arena in place once at least half of it, and
+// at least compactionMinStale bytes, are stale, reclaiming space left behind by overwritten or deleted
+// records. Callers must hold s.mu.
+func (s *byteShard) maybeCompact() {
+	if s.stale < compactionMinStale || float64(s.stale) < float64(len(s.arena))*compactionStaleRatio {
+		return
+	}
+	rebuilt := make([]byte, 0, len(s.arena)-s.stale)
+	for hashedKey, offset := range s.offsets {
+		length := recordLen(s.arena, offset)
+		s.offsets[hashedKey] = len(rebuilt)
+		rebuilt = append(rebuilt, s.arena[offset:offset+length]...)
+	}
+	s.arena = rebuilt
+	s.stale = 0
+}
+
+// bytesStorage implements Storage on top of one byteShard per
+// configured shard, chosen by hashing the key.
+type bytesStorage struct {
+	options BytesStorageOptions
+	shards  []*byteShard
+}
+
+func (b *bytesStorage) shardFor(hashedKey uint64) *byteShard {
+	return b.shards[hashedKey%uint64(len(b.shards))]
+}
+
+func (b *bytesStorage) Get(key interface{}) (*cacheEntry, bool) {
+	hashedKey := b.options.HashCodeFunc(key)
+	shard := b.shardFor(hashedKey)
+
+	shard.mu.Lock()
+	offset, exists := shard.offsets[hashedKey]
+	if !exists {
+		shard.mu.Unlock()
+		return nil, false
+	}
+	record := append([]byte(nil), shard.arena[offset:offset+recordLen(shard.arena, offset)]...)
+	shard.mu.Unlock()
+
+	negative, lastRead, lastWrite, storedKeyBytes, valueBytes := decodeRecord(record)
+
+	// Guard against a hash collision: compare the stored key bytes
+	// against the requested key instead of trusting the hash alone.
+	keyBytes, err := gobMarshal(key)
+	if err != nil || !bytes.Equal(keyBytes, storedKeyBytes) {
+		if b.options.Verbose {
+			b.options.Logger("loadingcache: bytes storage collision for hash %d, treating as a miss", hashedKey)
+		}
+		return nil, false
+	}
+
+	if negative {
+		return &cacheEntry{
+			key:         key,
+			negative:    true,
+			negativeErr: errors.New(string(valueBytes)),
+			lastRead:    lastRead,
+			lastWrite:   lastWrite,
+		}, true
+	}
+
+	value, err := b.options.Unmarshal(valueBytes)
+	if err != nil {
+		if b.options.Verbose {
+			b.options.Logger("loadingcache: failed to unmarshal value for key %v: %v", key, err)
+		}
+		return nil, false
+	}
+
+	return &cacheEntry{
+		key:       key,
+		value:     value,
+		lastRead:  lastRead,
+		lastWrite: lastWrite,
+	}, true
+}
+
+func (b *bytesStorage) Put(key interface{}, entry *cacheEntry) {
+	hashedKey := b.options.HashCodeFunc(key)
+	shard := b.shardFor(hashedKey)
+
+	keyBytes, err := gobMarshal(key)
+	if err != nil {
+		return
+	}
+	var valueBytes []byte
+	if entry.negative {
+		valueBytes = []byte(entry.negativeErr.Error())
+	} else {
+		valueBytes, err = b.options.Marshal(entry.value)
+		if err != nil {
+			return
+		}
+	}
+	record := encodeRecord(entry.negative, entry.lastRead, entry.lastWrite, keyBytes, valueBytes)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if oldOffset, exists := shard.offsets[hashedKey]; exists {
+		shard.stale += recordLen(shard.arena, oldOffset)
+	}
+	offset := len(shard.arena)
+	shard.arena = append(shard.arena, record...)
+	shard.offsets[hashedKey] = offset
+	shard.maybeCompact()
+}
+
+func (b *bytesStorage) Delete(key interface{}) {
+	hashedKey := b.options.HashCodeFunc(key)
+	shard := b.shardFor(hashedKey)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	// The arena slot is left in place; only the index is dropped. The
+	// orphaned bytes are counted as stale and reclaimed by maybeCompact
+	// once they pile up.
+	if offset, exists := shard.offsets[hashedKey]; exists {
+		shard.stale += recordLen(shard.arena, offset)
+		delete(shard.offsets, hashedKey)
+	}
+	shard.maybeCompact()
+}
+
+func (b *bytesStorage) ForEach(fn func(entry *cacheEntry) bool) {
+	for _, shard := range b.shards {
+		shard.mu.Lock()
+		offsets := make(map[uint64]int, len(shard.offsets))
+		for h, o := range shard.offsets {
+			offsets[h] = o
+		}
+		arena := shard.arena
+		shard.mu.Unlock()
+
+		for _, offset := range offsets {
+			record := arena[offset : offset+recordLen(arena, offset)]
+			negative, lastRead, lastWrite, keyBytes, valueBytes := decodeRecord(record)
+			key, err := gobUnmarshal(keyBytes)
+			if err != nil {
+				continue
+			}
+			if negative {
+				entry := &cacheEntry{
+					key:         key,
+					negative:    true,
+					negativeErr: errors.New(string(valueBytes)),
+					lastRead:    lastRead,
+					lastWrite:   lastWrite,
+				}
+				if !fn(entry) {
+					return
+				}
+				continue
+			}
+			value, err := b.options.Unmarshal(valueBytes)
+			if err != nil {
+				continue
+			}
+			if !fn(&cacheEntry{key: key, value: value, lastRead: lastRead, lastWrite: lastWrite}) {
+				return
+			}
+		}
+	}
+}
+
+func (b *bytesStorage) Len() int {
+	total := 0
+	for _, shard := range b.shards {
+		shard.mu.Lock()
+		total += len(shard.offsets)
+		shard.mu.Unlock()
+	}
+	return total
+}
+
+func recordLen(arena []byte, offset int) int {
+	return int(binary.BigEndian.Uint32(arena[offset:offset+4])) + 4
+}
+
+func encodeRecord(negative bool, lastRead, lastWrite time.Time, keyBytes, valueBytes []byte) []byte {
+	header := 1 + 8 + 8 + 4 // negative + lastRead + lastWrite + keyLen
+	body := header + len(keyBytes) + len(valueBytes)
+
+	record := make([]byte, 4+body)
+	binary.BigEndian.PutUint32(record[0:4], uint32(body))
+	if negative {
+		record[4] = 1
+	}
+	binary.BigEndian.PutUint64(record[5:13], uint64(lastRead.UnixNano()))
+	binary.BigEndian.PutUint64(record[13:21], uint64(lastWrite.UnixNano()))
+	binary.BigEndian.PutUint32(record[21:25], uint32(len(keyBytes)))
+	copy(record[25:25+len(keyBytes)], keyBytes)
+	copy(record[25+len(keyBytes):], valueBytes)
+	return record
+}
+
+func decodeRecord(record []byte) (negative bool, lastRead, lastWrite time.Time, keyBytes, valueBytes []byte) {
+	negative = record[4] == 1
+	lastRead = time.Unix(0, int64(binary.BigEndian.Uint64(record[5:13])))
+	lastWrite = time.Unix(0, int64(binary.BigEndian.Uint64(record[13:21])))
+	keyLen := int(binary.BigEndian.Uint32(record[21:25]))
+	keyBytes = record[25 : 25+keyLen]
+	valueBytes = record[25+keyLen:]
+	return
+}
@@ -0,0 +1,86 @@
+package loadingcache
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestBytesStorageRoundTripsNegativeEntries(t *testing.T) {
+	newStorage := NewBytesStorage(BytesStorageOptions{})
+	storage := newStorage()
+
+	loadErr := errors.New("boom")
+	storage.Put("k", &cacheEntry{key: "k", negative: true, negativeErr: loadErr})
+
+	entry, exists := storage.Get("k")
+	if !exists {
+		t.Fatalf("Get: expected the negative entry to exist")
+	}
+	if !entry.negative {
+		t.Fatalf("Get: expected a negative entry, got a regular value %v", entry.value)
+	}
+	if entry.negativeErr == nil || entry.negativeErr.Error() != loadErr.Error() {
+		t.Fatalf("Get: expected negativeErr %q, got %v", loadErr, entry.negativeErr)
+	}
+}
+
+func TestBytesStorageRoundTripsRegularEntries(t *testing.T) {
+	newStorage := NewBytesStorage(BytesStorageOptions{})
+	storage := newStorage()
+
+	storage.Put("k", &cacheEntry{key: "k", value: 42})
+
+	entry, exists := storage.Get("k")
+	if !exists {
+		t.Fatalf("Get: expected the entry to exist")
+	}
+	if entry.negative {
+		t.Fatalf("Get: expected a regular entry, got a negative one with err %v", entry.negativeErr)
+	}
+	if entry.value != 42 {
+		t.Fatalf("Get: expected value 42, got %v", entry.value)
+	}
+}
+
+// TestBytesStorageCompactsRepeatedOverwrites guards against the arena
+// growing without bound when the same key is put over and over, e.g. a
+// hot key kept fresh forever by RefreshAfterWrite: without compaction
+// each Put leaves its predecessor's bytes behind forever, so the arena
+// grows linearly with the number of writes instead of staying bounded.
+func TestBytesStorageCompactsRepeatedOverwrites(t *testing.T) {
+	newStorage := NewBytesStorage(BytesStorageOptions{})
+	storage := newStorage().(*bytesStorage)
+	shard := storage.shards[0]
+
+	arenaLenAfter := func(start, puts int) int {
+		for i := start; i < start+puts; i++ {
+			storage.Put("k", &cacheEntry{key: "k", value: i})
+		}
+		shard.mu.Lock()
+		defer shard.mu.Unlock()
+		return len(shard.arena)
+	}
+
+	const batch = 1000
+	first := arenaLenAfter(0, batch)
+	second := arenaLenAfter(batch, batch)
+
+	// Without compaction, doubling the number of overwrites roughly
+	// doubles the arena size (52808 bytes observed for 1000 uncompacted
+	// puts of a single key, i.e. it grows linearly with the put count).
+	// With compaction the arena is bounded independent of how many
+	// times the key was overwritten, so the second batch should leave
+	// it close to where it started rather than anywhere near 2x.
+	if second > first+first/2 {
+		t.Fatalf("expected the arena to stay bounded across repeated overwrites, got %d bytes after %d puts and %d bytes after %d puts", first, batch, second, 2*batch)
+	}
+
+	entry, exists := storage.Get("k")
+	if !exists {
+		t.Fatalf("Get: expected the entry to still exist after compaction")
+	}
+	if entry.value != 2*batch-1 {
+		t.Fatalf("Get: expected the latest value %d, got %v", 2*batch-1, entry.value)
+	}
+}
@@ -11,7 +11,6 @@
 package loadingcache
 
 import (
-	"fmt"
 	"sync"
 	"time"
 
@@ -40,6 +39,11 @@ const (
 
 	// RemovalReasonSize means the entry was removed due to the cache size.
 	RemovalReasonSize RemovalReason = "SIZE"
+
+	// RemovalReasonRemote means the entry was invalidated because of an
+	// event received through CacheOptions.EventBus from another cache
+	// instance.
+	RemovalReasonRemote RemovalReason = "REMOTE"
 )
 
 // RemovalNotification is passed to listeners everytime an entry is removed
@@ -52,6 +56,10 @@ type RemovalNotification struct {
 // RemovalListener represents a removal listener
 type RemovalListener func(RemovalNotification)
 
+// Stats is the read-only view of a cache's statistics, as returned by
+// Cache.Stats.
+type Stats = stats.Stats
+
 // Cache describe the base interface to interact with a generic cache.
 //
 // This interface reduces all keys and values to a generic interface{}.
@@ -72,6 +80,12 @@ type Cache interface {
 	// InvalidateAll invalidates all keys
 	InvalidateAll()
 
+	// Refresh triggers an asynchronous reload of key through Load,
+	// coalesced with any other in-flight load or refresh for the same
+	// key. It is a noop if Load is not configured. See
+	// CacheOptions.RefreshAfterWrite for reloading automatically.
+	Refresh(key interface{})
+
 	// Close cleans up any resources used by the cache
 	Close()
 
@@ -130,6 +144,67 @@ type CacheOptions struct {
 	// The background go routine runs with the provided frequency.
 	// To avoid go routine leaks, use the close function when you're done with the cache.
 	BackgroundEvictFrequency time.Duration
+
+	// NewEvictionPolicy, when set, is used to build the EvictionPolicy that
+	// picks which entry to remove once MaxSize is reached. It is invoked
+	// once per shard, so every shard gets its own independent policy state.
+	//
+	// If not specified, the cache falls back to evicting a random entry,
+	// since maps do not have a deterministic order.
+	NewEvictionPolicy func() EvictionPolicy
+
+	// NewStorage, when set, is used to build the Storage backing a shard.
+	// It is invoked once per shard, so every shard gets its own
+	// independent storage.
+	//
+	// If not specified, the cache uses an in-memory
+	// map[interface{}]*cacheEntry. Use NewBytesStorage to reduce GC
+	// pressure on caches with a large number of entries.
+	NewStorage func() Storage
+
+	// NegativeCacheTTL, when set together with NegativeCacheErrors,
+	// configures how long a failed load is remembered for. While a
+	// negative entry for a key is valid, Get returns the cached error
+	// straight away instead of calling Load again, protecting a failing
+	// backend from a thundering herd of retries.
+	NegativeCacheTTL time.Duration
+
+	// NegativeCacheErrors decides which errors returned by Load are
+	// worth caching as negative entries. Load errors for which this
+	// returns false (or if NegativeCacheErrors is nil) are never cached
+	// and every call keeps retrying Load.
+	NegativeCacheErrors func(error) bool
+
+	// RefreshAfterWrite configures the cache with Guava's
+	// refreshAfterWrite semantics: once an entry is older than this
+	// duration (but not yet expired), Get returns the stale value right
+	// away and schedules an asynchronous Load to refresh it in the
+	// background, coalesced via singleflight so only one refresh per key
+	// is ever in flight.
+	RefreshAfterWrite time.Duration
+
+	// RefreshErrorHandler, when set, is called with any error returned
+	// by Load while refreshing a key, whether triggered by
+	// RefreshAfterWrite or by an explicit call to Refresh. The stale
+	// value already in the cache is left untouched.
+	RefreshErrorHandler func(key interface{}, err error)
+
+	// EventBus, when set, is used to broadcast every Put and Invalidate
+	// so other cache instances subscribed to the same bus drop their
+	// local copy of the affected keys, and to receive the same
+	// broadcasts from them. Defaults to a noop bus that never publishes
+	// or receives anything.
+	EventBus EventBus
+
+	// MetricsCollector, when set, receives a callback for every hit,
+	// miss, load, refresh and eviction, as well as the current size
+	// after every write. See the metrics/prometheus and metrics/otel
+	// subpackages for ready-made implementations.
+	MetricsCollector MetricsCollector
+}
+
+func (c CacheOptions) refreshesAfterWrite() bool {
+	return c.RefreshAfterWrite > 0
 }
 
 func (c CacheOptions) expiresAfterRead() bool {
@@ -140,6 +215,10 @@ func (c CacheOptions) expiresAfterWrite() bool {
 	return c.ExpireAfterWrite > 0
 }
 
+func (c CacheOptions) negativeCaching() bool {
+	return c.NegativeCacheTTL > 0 && c.NegativeCacheErrors != nil
+}
+
 // CacheOption describes an option that can configure the cache
 type CacheOption func(Cache)
 
@@ -151,6 +230,11 @@ type cacheEntry struct {
 	value     interface{}
 	lastRead  time.Time
 	lastWrite time.Time
+
+	// negative marks this entry as a cached Load failure rather than a
+	// real value. See CacheOptions.NegativeCacheTTL.
+	negative    bool
+	negativeErr error
 }
 
 // New instantiates a new cache
@@ -167,10 +251,26 @@ func New(options CacheOptions) Cache {
 	case 0, 1:
 		c := &genericCache{
 			CacheOptions: options,
-			data:         map[interface{}]*cacheEntry{},
 			done:         make(chan struct{}),
 			stats:        &stats.InternalStats{},
 		}
+		if options.NewStorage != nil {
+			c.storage = options.NewStorage()
+		} else {
+			c.storage = newMapStorage()
+		}
+		if options.NewEvictionPolicy != nil {
+			c.evictionPolicy = options.NewEvictionPolicy()
+		}
+		c.eventBus = options.EventBus
+		if c.eventBus == nil {
+			c.eventBus = NewNoopEventBus()
+		}
+		c.eventBus.Subscribe(c.onRemoteEvent)
+		c.metrics = options.MetricsCollector
+		if c.metrics == nil {
+			c.metrics = noopMetricsCollector{}
+		}
 		if options.BackgroundEvictFrequency > 0 {
 			c.backgroundWg.Add(1)
 			go c.runBackgroundEvict()
@@ -186,6 +286,21 @@ func New(options CacheOptions) Cache {
 			CacheOptions: options,
 			shards:       make([]Cache, options.ShardCount),
 		}
+		// MetricsCollector is a single shared value, not a per-shard
+		// factory like NewStorage/NewEvictionPolicy, so every shard
+		// would otherwise report its own absolute Size independently
+		// and stomp on the others. Route it through an aggregator that
+		// sums every shard's last reported size before forwarding a
+		// single Size call for the whole cache.
+		if options.MetricsCollector != nil {
+			aggregator := newShardSizeAggregator(options.MetricsCollector, options.ShardCount)
+			for i := 0; i < options.ShardCount; i++ {
+				shardOptions := singleShardOptions
+				shardOptions.MetricsCollector = aggregator.forShard(i)
+				s.shards[i] = New(shardOptions)
+			}
+			return s
+		}
 		for i := 0; i < options.ShardCount; i++ {
 			s.shards[i] = New(singleShardOptions)
 		}
@@ -220,6 +335,10 @@ func (s *shardedCache) InvalidateAll() {
 	}
 }
 
+func (s *shardedCache) Refresh(key interface{}) {
+	s.shards[s.HashCodeFunc(key)%len(s.shards)].Refresh(key)
+}
+
 func (s *shardedCache) Close() {
 	for _, shard := range s.shards {
 		shard.Close()
@@ -227,14 +346,9 @@ func (s *shardedCache) Close() {
 }
 
 func (s *shardedCache) Stats() Stats {
-	statsSum := &stats.InternalStats{}
+	var statsSum Stats = &stats.InternalStats{}
 	for _, shard := range s.shards {
-		switch typedCache := shard.(type) {
-		case *genericCache:
-			statsSum = statsSum.Add(typedCache.stats)
-		default:
-			panic(fmt.Sprintf("unsupported cache type %T", shard))
-		}
+		statsSum = statsSum.Add(shard.Stats())
 	}
 	return statsSum
 }
@@ -244,16 +358,34 @@ func (s *shardedCache) Stats() Stats {
 type genericCache struct {
 	CacheOptions
 
-	data     map[interface{}]*cacheEntry
+	storage  Storage
 	dataLock sync.RWMutex
 
 	done         chan struct{}
 	backgroundWg sync.WaitGroup
 
 	stats *stats.InternalStats
+
+	evictionPolicy EvictionPolicy
+
+	// loadGroup coalesces concurrent Load calls for the same key.
+	loadGroup singleflightGroup
+
+	eventBus EventBus
+	metrics  MetricsCollector
+}
+
+// onRemoteEvent is subscribed to eventBus and drops the local copy of a
+// key invalidated or overwritten by another cache instance, without
+// republishing the event back onto the bus.
+func (g *genericCache) onRemoteEvent(key interface{}) {
+	g.concurrentEvict(key, RemovalReasonRemote)
 }
 
 func (g *genericCache) isExpired(entry *cacheEntry) bool {
+	if entry.negative {
+		return entry.lastWrite.Add(g.NegativeCacheTTL).Before(g.Clock.Now())
+	}
 	if g.expiresAfterRead() && entry.lastRead.Add(g.ExpireAfterRead).Before(g.Clock.Now()) {
 		return true
 	}
@@ -263,9 +395,16 @@ func (g *genericCache) isExpired(entry *cacheEntry) bool {
 	return false
 }
 
+// needsRefresh reports whether entry is older than RefreshAfterWrite,
+// meaning Get should serve it as-is but kick off a background reload.
+func (g *genericCache) needsRefresh(entry *cacheEntry) bool {
+	return !entry.negative && g.refreshesAfterWrite() &&
+		entry.lastWrite.Add(g.RefreshAfterWrite).Before(g.Clock.Now())
+}
+
 func (g *genericCache) Get(key interface{}) (interface{}, error) {
 	g.dataLock.RLock()
-	entry, exists := g.data[key]
+	entry, exists := g.storage.Get(key)
 	if !exists {
 		g.dataLock.RUnlock()
 		val, err := g.load(key)
@@ -273,6 +412,8 @@ func (g *genericCache) Get(key interface{}) (interface{}, error) {
 	}
 	// Create a copy of the value to return to avoid concurrent updates
 	toReturn := entry.value
+	negative, negativeErr := entry.negative, entry.negativeErr
+	needsRefresh := g.needsRefresh(entry)
 	g.dataLock.RUnlock()
 
 	if g.isExpired(entry) {
@@ -280,47 +421,148 @@ func (g *genericCache) Get(key interface{}) (interface{}, error) {
 		val, err := g.load(key)
 		return val, errors.Wrap(err, "")
 	}
+	if needsRefresh {
+		g.Refresh(key)
+	}
 	// It is possible that this will race. It will only be a problem
 	// if the expiry thresholds have to be respected with a high
 	// degree of precision (which is subjective).
 	entry.lastRead = g.Clock.Now()
 	g.stats.Hit()
+	g.metrics.Hit()
+	if g.evictionPolicy != nil {
+		g.evictionPolicy.OnGet(key)
+	}
+	if negative {
+		return nil, errors.Wrap(negativeErr, "")
+	}
 	return toReturn, nil
 }
 
+// load fetches key through LoadFunc, coalescing concurrent calls for the
+// same key into a single LoadFunc invocation so that unrelated keys
+// never wait on each other.
 func (g *genericCache) load(key interface{}) (interface{}, error) {
-	g.dataLock.Lock()
-	defer g.dataLock.Unlock()
+	val, err, _ := g.loadGroup.Do(key, func() (interface{}, error) {
+		return g.doLoad(key)
+	})
+	return val, err
+}
 
-	// It is possible that another call loaded the value for this key.
-	// Let's do a double check if that was the case, since we have
-	// the lock.
-	if entry, exists := g.data[key]; exists {
+func (g *genericCache) doLoad(key interface{}) (interface{}, error) {
+	// It is possible that another call already loaded the value for
+	// this key, e.g. via Put, while we were not yet coalesced onto the
+	// same singleflight call. Let's do a double check first.
+	g.dataLock.RLock()
+	if entry, exists := g.storage.Get(key); exists && !g.isExpired(entry) {
+		g.dataLock.RUnlock()
 		g.stats.Hit()
+		g.metrics.Hit()
+		if entry.negative {
+			return nil, errors.Wrap(entry.negativeErr, "")
+		}
 		return entry.value, nil
-	} else if g.Load == nil {
+	}
+	g.dataLock.RUnlock()
+
+	if g.Load == nil {
 		g.stats.Miss()
+		g.metrics.Miss()
 		return nil, errors.Wrap(ErrKeyNotFound, "")
 	}
 
 	loadStartTime := g.Clock.Now()
 	val, err := g.Load(key)
+	loadDuration := g.Clock.Now().Sub(loadStartTime)
 	if err != nil {
 		g.stats.LoadError()
+		g.metrics.LoadError(loadDuration)
+		if g.negativeCaching() && g.NegativeCacheErrors(err) {
+			g.putNegative(key, err)
+		}
 		return nil, errors.Wrapf(err, "failed to load key %v", key)
 	}
-	g.stats.LoadTime(g.Clock.Now().Sub(loadStartTime))
+	g.stats.LoadTime(loadDuration)
 	g.stats.LoadSuccess()
+	g.metrics.LoadSuccess(loadDuration)
+	g.dataLock.Lock()
 	g.internalPut(key, val)
+	g.dataLock.Unlock()
 	return val, nil
 }
 
+// putNegative caches err as the result of loading key, so concurrent and
+// future Gets within NegativeCacheTTL receive it without calling Load
+// again.
+func (g *genericCache) putNegative(key interface{}, err error) {
+	g.dataLock.Lock()
+	defer g.dataLock.Unlock()
+	now := g.Clock.Now()
+	g.internalPutEntry(&cacheEntry{
+		key:         key,
+		lastRead:    now,
+		lastWrite:   now,
+		negative:    true,
+		negativeErr: err,
+	})
+}
+
 func (g *genericCache) concurrentEvict(key interface{}, reason RemovalReason) {
 	g.dataLock.Lock()
 	defer g.dataLock.Unlock()
 	g.evict(key, reason)
 }
 
+// Refresh triggers an asynchronous reload of key through Load. It is a
+// noop if Load is not configured. The reload is coalesced through the
+// same singleflight group used for regular loads, so a refresh in
+// flight for a key absorbs any other refresh or miss requested for it
+// in the meantime.
+func (g *genericCache) Refresh(key interface{}) {
+	if g.Load == nil {
+		return
+	}
+	go func() {
+		// Errors are surfaced through RefreshErrorHandler and the
+		// stats subsystem instead of a return value, since nothing is
+		// waiting on this goroutine.
+		_, _, _ = g.loadGroup.Do(key, func() (interface{}, error) {
+			return g.doRefresh(key)
+		})
+	}()
+}
+
+func (g *genericCache) doRefresh(key interface{}) (interface{}, error) {
+	loadStartTime := g.Clock.Now()
+	val, err := g.Load(key)
+	loadDuration := g.Clock.Now().Sub(loadStartTime)
+	if err != nil {
+		g.stats.RefreshError()
+		g.metrics.RefreshError(loadDuration)
+		if g.RefreshErrorHandler != nil {
+			g.RefreshErrorHandler(key, err)
+		}
+		return nil, errors.Wrapf(err, "failed to refresh key %v", key)
+	}
+	g.stats.LoadTime(loadDuration)
+	g.stats.RefreshSuccess()
+	g.metrics.RefreshSuccess(loadDuration)
+
+	g.dataLock.Lock()
+	previous, existed := g.storage.Get(key)
+	g.internalPut(key, val)
+	g.dataLock.Unlock()
+
+	if existed {
+		g.notifyRemoval(RemovalNotification{
+			Key:    key,
+			Value:  previous.value,
+			Reason: RemovalReasonReplaced,
+		})
+	}
+	return val, nil
+}
+
 func (g *genericCache) runBackgroundEvict() {
 	ticker := g.Clock.Ticker(g.BackgroundEvictFrequency)
 	defer ticker.Stop()
@@ -340,38 +582,54 @@ func (g *genericCache) runBackgroundEvict() {
 func (g *genericCache) backgroundEvict() {
 	g.dataLock.Lock()
 	defer g.dataLock.Unlock()
-	for key := range g.data {
-		entry := g.data[key]
+	var toEvict []interface{}
+	g.storage.ForEach(func(entry *cacheEntry) bool {
 		if g.isExpired(entry) {
 			// TODO: There's a possibility that we want to evict
 			// in a go routine so we can get through
 			// all expired entries as fast as possible without
 			// having to sequentially wait for removal listeners.
-			g.evict(entry.key, RemovalReasonExpired)
+			toEvict = append(toEvict, entry.key)
 		}
+		return true
+	})
+	for _, key := range toEvict {
+		g.evict(key, RemovalReasonExpired)
 	}
 }
 
 func (g *genericCache) evict(key interface{}, reason RemovalReason) {
-	val, exists := g.data[key]
+	val, exists := g.storage.Get(key)
 	if !exists {
 		return
 	}
 	g.stats.Eviction()
-	delete(g.data, key)
-
-	if len(g.RemovalListeners) == 0 {
-		return
+	g.storage.Delete(key)
+	// RemovalReasonSize means key came from nextEviction(), i.e. the
+	// policy's own Evict(), which already updated its bookkeeping for
+	// it (and, for ghost-list policies like 2Q, may have deliberately
+	// kept a trace of it); calling OnDelete again here would undo that.
+	if g.evictionPolicy != nil && reason != RemovalReasonSize {
+		g.evictionPolicy.OnDelete(key)
 	}
-	notification := RemovalNotification{
+	g.metrics.Eviction(reason)
+	g.metrics.Size(g.storage.Len())
+
+	g.notifyRemoval(RemovalNotification{
 		Key:    key,
 		Value:  val.value,
 		Reason: reason,
+	})
+}
+
+// notifyRemoval fans notification out to every configured
+// RemovalListener, each on its own goroutine so a slow one does not
+// affect the others. This could potentially be early optimization, but
+// seems simple enough.
+func (g *genericCache) notifyRemoval(notification RemovalNotification) {
+	if len(g.RemovalListeners) == 0 {
+		return
 	}
-	// Each removal listener is called on its own goroutine
-	// so a slow one does not affect the others.
-	// This could potentially be early optimization, but seems
-	// simple enough.
 	var listenerWg sync.WaitGroup
 	listenerWg.Add(len(g.RemovalListeners))
 	for i := range g.RemovalListeners {
@@ -387,21 +645,44 @@ func (g *genericCache) evict(key interface{}, reason RemovalReason) {
 // internalPut actually saves the values into the internal structures.
 // It does not handle any synchronization, leaving that to the caller.
 func (g *genericCache) internalPut(key interface{}, value interface{}) {
-	if g.MaxSize > 0 && int32(len(g.data)) >= g.MaxSize {
-		// If eviction is needed it currently removes a random entry,
-		// since maps do not have a deterministic order.
-		// TODO: Apply smarter eviction policies if available
-		for toEvict := range g.data {
-			g.evict(toEvict, RemovalReasonSize)
-			break
-		}
-	}
-	g.data[key] = &cacheEntry{
+	g.internalPutEntry(&cacheEntry{
 		key:       key,
 		value:     value,
 		lastRead:  g.Clock.Now(),
 		lastWrite: g.Clock.Now(),
+	})
+}
+
+// internalPutEntry is the shared implementation behind internalPut and
+// putNegative. It does not handle any synchronization, leaving that to
+// the caller.
+func (g *genericCache) internalPutEntry(entry *cacheEntry) {
+	if g.MaxSize > 0 && int32(g.storage.Len()) >= g.MaxSize {
+		if toEvict, exists := g.nextEviction(); exists {
+			g.evict(toEvict, RemovalReasonSize)
+		}
+	}
+	g.storage.Put(entry.key, entry)
+	if g.evictionPolicy != nil {
+		g.evictionPolicy.OnPut(entry.key)
 	}
+	g.metrics.Size(g.storage.Len())
+}
+
+// nextEviction picks the next key to evict, deferring to the configured
+// EvictionPolicy if there is one. Without a policy it falls back to
+// removing a random entry, since maps do not have a deterministic order.
+func (g *genericCache) nextEviction() (interface{}, bool) {
+	if g.evictionPolicy != nil {
+		return g.evictionPolicy.Evict()
+	}
+	var key interface{}
+	var found bool
+	g.storage.ForEach(func(entry *cacheEntry) bool {
+		key, found = entry.key, true
+		return false
+	})
+	return key, found
 }
 
 // preWriteCleanup does a pass through all entries to assess if any are expired
@@ -412,37 +693,67 @@ func (g *genericCache) preWriteCleanup() {
 	if g.BackgroundEvictFrequency > 0 {
 		return
 	}
-	for key := range g.data {
-		if g.isExpired(g.data[key]) {
-			g.evict(key, RemovalReasonExpired)
+	var toEvict []interface{}
+	g.storage.ForEach(func(entry *cacheEntry) bool {
+		if g.isExpired(entry) {
+			toEvict = append(toEvict, entry.key)
 		}
+		return true
+	})
+	for _, key := range toEvict {
+		g.evict(key, RemovalReasonExpired)
 	}
 }
 
 func (g *genericCache) Put(key interface{}, value interface{}) {
 	g.dataLock.Lock()
-	defer g.dataLock.Unlock()
 	g.preWriteCleanup()
-	if _, exists := g.data[key]; exists {
+	if _, exists := g.storage.Get(key); exists {
 		g.evict(key, RemovalReasonReplaced)
 	}
 	g.internalPut(key, value)
+	g.dataLock.Unlock()
+
+	g.eventBus.Publish(key)
 }
 
 func (g *genericCache) Invalidate(key interface{}, keys ...interface{}) {
 	g.dataLock.Lock()
-	defer g.dataLock.Unlock()
-	delete(g.data, key)
+	g.storage.Delete(key)
+	if g.evictionPolicy != nil {
+		g.evictionPolicy.OnDelete(key)
+	}
+	for _, k := range keys {
+		g.storage.Delete(k)
+		if g.evictionPolicy != nil {
+			g.evictionPolicy.OnDelete(k)
+		}
+	}
+	g.dataLock.Unlock()
+
+	g.eventBus.Publish(key)
 	for _, k := range keys {
-		delete(g.data, k)
+		g.eventBus.Publish(k)
 	}
 }
 
 func (g *genericCache) InvalidateAll() {
 	g.dataLock.Lock()
-	defer g.dataLock.Unlock()
-	for key := range g.data {
-		delete(g.data, key)
+	var toDelete []interface{}
+	g.storage.ForEach(func(entry *cacheEntry) bool {
+		toDelete = append(toDelete, entry.key)
+		return true
+	})
+	for _, key := range toDelete {
+		g.storage.Delete(key)
+		if g.evictionPolicy != nil {
+			g.evictionPolicy.OnDelete(key)
+		}
+	}
+	g.dataLock.Unlock()
+
+	for _, key := range toDelete {
+		g.eventBus.Publish(key)
 	}
 }
 
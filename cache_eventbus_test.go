@@ -0,0 +1,68 @@
+package loadingcache
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// testEventBroker fans a Publish out to every other subscriber sharing
+// it, mirroring how the Redis EventBus relays a publish to every other
+// process on the channel. testEventBus wraps it with a per-instance id so
+// a cache never receives its own publish back, the same self-echo
+// suppression the Redis bus does via an instance UUID.
+type testEventBroker struct {
+	subs []struct {
+		id      string
+		handler func(key interface{})
+	}
+}
+
+type testEventBus struct {
+	broker *testEventBroker
+	id     string
+}
+
+func (b *testEventBus) Publish(key interface{}) {
+	for _, s := range b.broker.subs {
+		if s.id == b.id {
+			continue
+		}
+		s.handler(key)
+	}
+}
+
+func (b *testEventBus) Subscribe(handler func(key interface{})) {
+	b.broker.subs = append(b.broker.subs, struct {
+		id      string
+		handler func(key interface{})
+	}{id: b.id, handler: handler})
+}
+
+// TestCacheEventBusInvalidatesOtherInstance exercises EventBus wiring end
+// to end: a Put on one cache instance must evict the key from another
+// instance sharing the same bus, while each instance's own writes are
+// left alone, and an Invalidate must still remove the key locally.
+func TestCacheEventBusInvalidatesOtherInstance(t *testing.T) {
+	broker := &testEventBroker{}
+
+	a := New(CacheOptions{EventBus: &testEventBus{broker: broker, id: "a"}})
+	defer a.Close()
+	b := New(CacheOptions{EventBus: &testEventBus{broker: broker, id: "b"}})
+	defer b.Close()
+
+	a.Put("k", "from-a")
+	b.Put("k", "from-b")
+
+	if _, err := a.Get("k"); errors.Cause(err) != ErrKeyNotFound {
+		t.Fatalf("Get on a: expected a's copy of %q to be evicted by b's Put, got %v", "k", err)
+	}
+	if val, err := b.Get("k"); err != nil || val != "from-b" {
+		t.Fatalf("Get on b: expected b's own copy %q to survive its own Put, got (%v, %v)", "from-b", val, err)
+	}
+
+	b.Invalidate("k")
+	if _, err := b.Get("k"); errors.Cause(err) != ErrKeyNotFound {
+		t.Fatalf("Get on b: expected %q to be gone after Invalidate, got %v", "k", err)
+	}
+}
@@ -0,0 +1,84 @@
+package loadingcache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TestCacheCoalescesConcurrentMisses exercises singleflightGroup as Get
+// actually uses it: many goroutines missing on the same key concurrently
+// must coalesce onto a single Load call rather than each triggering their
+// own, e.g. a thundering herd of requests for a key that just expired.
+func TestCacheCoalescesConcurrentMisses(t *testing.T) {
+	var loads int32
+	block := make(chan struct{})
+	c := New(CacheOptions{
+		Load: func(key interface{}) (interface{}, error) {
+			atomic.AddInt32(&loads, 1)
+			<-block
+			return "v", nil
+		},
+	})
+	defer c.Close()
+
+	const callers = 20
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			val, err := c.Get("k")
+			if err != nil {
+				t.Errorf("Get: unexpected error %v", err)
+				return
+			}
+			if val != "v" {
+				t.Errorf("Get: expected %q, got %v", "v", val)
+			}
+		}()
+	}
+
+	// Give every goroutine a chance to reach Get and block on the
+	// in-flight Load before releasing it.
+	time.Sleep(50 * time.Millisecond)
+	close(block)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&loads); got != 1 {
+		t.Fatalf("expected Load to run once for %d concurrent misses, ran %d times", callers, got)
+	}
+}
+
+// TestCacheServesNegativeEntryWithoutReloading exercises negative
+// caching end to end through Get: once a failed Load is cached, Get must
+// keep returning that error without calling Load again until the entry
+// expires, protecting a failing backend from repeated retries.
+func TestCacheServesNegativeEntryWithoutReloading(t *testing.T) {
+	var loads int32
+	loadErr := errors.New("backend down")
+	c := New(CacheOptions{
+		Load: func(key interface{}) (interface{}, error) {
+			atomic.AddInt32(&loads, 1)
+			return nil, loadErr
+		},
+		NegativeCacheTTL: time.Minute,
+		NegativeCacheErrors: func(error) bool {
+			return true
+		},
+	})
+	defer c.Close()
+
+	if _, err := c.Get("k"); errors.Cause(err).Error() != loadErr.Error() {
+		t.Fatalf("Get: expected %v, got %v", loadErr, err)
+	}
+	if _, err := c.Get("k"); errors.Cause(err).Error() != loadErr.Error() {
+		t.Fatalf("Get: expected cached %v, got %v", loadErr, err)
+	}
+	if got := atomic.LoadInt32(&loads); got != 1 {
+		t.Fatalf("expected Load to run once while the negative entry is valid, ran %d times", got)
+	}
+}
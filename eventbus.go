@@ -0,0 +1,32 @@
+package loadingcache
+
+// EventBus lets multiple cache instances - e.g. across a horizontally
+// scaled service sharing the same backing datastore - invalidate each
+// other's local copy of a key after a Put or Invalidate, so that using
+// loadingcache as an L1 in front of that datastore does not risk serving
+// stale reads after a write lands on a different instance.
+type EventBus interface {
+	// Publish announces that key was written or invalidated locally, so
+	// other participants can drop their own local copy.
+	Publish(key interface{})
+
+	// Subscribe registers handler to be called for every key published
+	// by another participant. Implementations must support multiple
+	// subscribers on the same instance, since a sharded cache subscribes
+	// once per shard even when every shard shares the same EventBus.
+	Subscribe(handler func(key interface{}))
+}
+
+// noopEventBus is the default EventBus: it never publishes anywhere and
+// never invokes a subscriber, so a cache without a configured EventBus
+// behaves exactly as it did before EventBus existed.
+type noopEventBus struct{}
+
+// NewNoopEventBus creates an in-process EventBus that does nothing. It
+// is the default used when CacheOptions.EventBus is left unset.
+func NewNoopEventBus() EventBus {
+	return noopEventBus{}
+}
+
+func (noopEventBus) Publish(interface{})         {}
+func (noopEventBus) Subscribe(func(interface{})) {}
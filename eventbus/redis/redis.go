@@ -0,0 +1,169 @@
+// Package redis provides a loadingcache.EventBus backed by Redis
+// pub/sub, so caches running in different processes or on different
+// hosts can invalidate each other after a Put or Invalidate.
+package redis
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+
+	"github.com/Hartimer/loadingcache"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Options configures an EventBus.
+type Options struct {
+	// Client is the Redis client used to publish and subscribe.
+	Client *redis.Client
+
+	// Channel is the pub/sub channel events are exchanged on. Defaults
+	// to "loadingcache:invalidate" if empty.
+	Channel string
+
+	// Context is used for the Publish calls and the subscription's
+	// receive loop. Defaults to context.Background() if nil.
+	Context context.Context
+
+	// KeyMarshal serializes a key before it is published, so its
+	// concrete type survives the round trip over Redis. Defaults to gob
+	// encoding. Must be the inverse of KeyUnmarshal.
+	KeyMarshal loadingcache.MarshalFunc
+
+	// KeyUnmarshal deserializes a key received from a subscription back
+	// into the type KeyMarshal was given. Defaults to gob decoding.
+	KeyUnmarshal loadingcache.UnmarshalFunc
+}
+
+// message is what actually travels over the wire: the invalidated key,
+// already serialized by KeyMarshal so its concrete type survives the
+// round trip, plus the instance ID of whoever published it, so a node
+// can recognize and ignore its own writes echoed back by Redis.
+//
+// The key is carried as bytes rather than interface{} because
+// encoding/json decodes any interface{} number back as float64,
+// silently turning e.g. an int key into something that no longer
+// matches it in a map lookup.
+type message struct {
+	InstanceID string `json:"instance_id"`
+	Key        []byte `json:"key"`
+}
+
+func gobMarshalKey(key interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&key); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gobUnmarshalKey(data []byte) (interface{}, error) {
+	var key interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// eventBus implements loadingcache.EventBus on top of a Redis pub/sub
+// channel.
+type eventBus struct {
+	client       *redis.Client
+	channel      string
+	ctx          context.Context
+	instanceID   string
+	keyMarshal   loadingcache.MarshalFunc
+	keyUnmarshal loadingcache.UnmarshalFunc
+}
+
+const defaultChannel = "loadingcache:invalidate"
+
+// New creates a loadingcache.EventBus backed by Redis pub/sub. Every
+// published message is tagged with a per-process instance UUID so a
+// node ignores events that originated from itself.
+func New(options Options) (*eventBus, error) {
+	if options.Client == nil {
+		return nil, errors.New("redis eventbus: Client is required")
+	}
+	channel := options.Channel
+	if channel == "" {
+		channel = defaultChannel
+	}
+	ctx := options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, errors.Wrap(err, "generating instance id")
+	}
+	keyMarshal := options.KeyMarshal
+	if keyMarshal == nil {
+		keyMarshal = gobMarshalKey
+	}
+	keyUnmarshal := options.KeyUnmarshal
+	if keyUnmarshal == nil {
+		keyUnmarshal = gobUnmarshalKey
+	}
+	return &eventBus{
+		client:       options.Client,
+		channel:      channel,
+		ctx:          ctx,
+		instanceID:   id.String(),
+		keyMarshal:   keyMarshal,
+		keyUnmarshal: keyUnmarshal,
+	}, nil
+}
+
+// Publish implements loadingcache.EventBus.
+func (b *eventBus) Publish(key interface{}) {
+	keyBytes, err := b.keyMarshal(key)
+	if err != nil {
+		return
+	}
+	payload, err := json.Marshal(message{InstanceID: b.instanceID, Key: keyBytes})
+	if err != nil {
+		return
+	}
+	// Best-effort: a dropped invalidation just means a remote node
+	// keeps a stale entry until it naturally expires.
+	b.client.Publish(b.ctx, b.channel, payload)
+}
+
+// Subscribe implements loadingcache.EventBus. It starts a background
+// goroutine reading from the Redis subscription for as long as Context
+// is not canceled.
+func (b *eventBus) Subscribe(handler func(key interface{})) {
+	sub := b.client.Subscribe(b.ctx, b.channel)
+	ch := sub.Channel()
+	go func() {
+		defer sub.Close()
+		for {
+			select {
+			case <-b.ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				var m message
+				if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+					continue
+				}
+				if m.InstanceID == b.instanceID {
+					// Self-echo: we already invalidated locally before
+					// publishing.
+					continue
+				}
+				key, err := b.keyUnmarshal(m.Key)
+				if err != nil {
+					continue
+				}
+				handler(key)
+			}
+		}
+	}()
+}
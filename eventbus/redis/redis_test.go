@@ -0,0 +1,21 @@
+package redis
+
+import "testing"
+
+func TestGobKeyRoundTripPreservesType(t *testing.T) {
+	data, err := gobMarshalKey(42)
+	if err != nil {
+		t.Fatalf("gobMarshalKey: %v", err)
+	}
+	key, err := gobUnmarshalKey(data)
+	if err != nil {
+		t.Fatalf("gobUnmarshalKey: %v", err)
+	}
+	intKey, ok := key.(int)
+	if !ok {
+		t.Fatalf("expected an int, got %T", key)
+	}
+	if intKey != 42 {
+		t.Fatalf("expected 42, got %d", intKey)
+	}
+}
@@ -0,0 +1,421 @@
+package loadingcache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// EvictionPolicy decides which key should be evicted once a cache has
+// reached its configured MaxSize. Implementations are notified of every
+// read, write and delete so they can keep whatever bookkeeping they need
+// (recency lists, frequency counts, ghost entries, ...) up to date.
+//
+// Implementations must be safe for concurrent use, since Get can invoke
+// OnGet without holding the cache's write lock.
+type EvictionPolicy interface {
+	// OnGet is called whenever a key is read from the cache, whether or
+	// not the policy already knows about it.
+	OnGet(key interface{})
+
+	// OnPut is called whenever a key is written to the cache, including
+	// updates to an already present key.
+	OnPut(key interface{})
+
+	// OnDelete is called whenever a key is removed from the cache, except
+	// for a key the policy itself just returned from Evict: that eviction
+	// is left to Evict's own bookkeeping.
+	OnDelete(key interface{})
+
+	// Evict picks a key to be removed to make room for a new entry.
+	// It returns false if the policy has no key to offer.
+	Evict() (interface{}, bool)
+}
+
+// lruPolicy implements EvictionPolicy using a doubly-linked list where the
+// most recently used key sits at the front. Both Get and Put move the
+// corresponding key to the front, so the back of the list is always the
+// least recently used key.
+type lruPolicy struct {
+	mu    sync.Mutex
+	ll    *list.List
+	items map[interface{}]*list.Element
+}
+
+// NewLRUPolicy creates an EvictionPolicy that evicts the least recently
+// used key, moving a key to the front of an internal list on every Get
+// or Put.
+func NewLRUPolicy() EvictionPolicy {
+	return &lruPolicy{
+		ll:    list.New(),
+		items: map[interface{}]*list.Element{},
+	}
+}
+
+func (p *lruPolicy) OnGet(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if elem, exists := p.items[key]; exists {
+		p.ll.MoveToFront(elem)
+	}
+}
+
+func (p *lruPolicy) OnPut(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if elem, exists := p.items[key]; exists {
+		p.ll.MoveToFront(elem)
+		return
+	}
+	p.items[key] = p.ll.PushFront(key)
+}
+
+func (p *lruPolicy) OnDelete(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if elem, exists := p.items[key]; exists {
+		p.ll.Remove(elem)
+		delete(p.items, key)
+	}
+}
+
+func (p *lruPolicy) Evict() (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	back := p.ll.Back()
+	if back == nil {
+		return nil, false
+	}
+	p.ll.Remove(back)
+	key := back.Value
+	delete(p.items, key)
+	return key, true
+}
+
+// lfuEntry tracks the access frequency of a single key.
+type lfuEntry struct {
+	key  interface{}
+	freq int
+}
+
+// lfuPolicy implements EvictionPolicy using frequency-bucketed lists, so
+// that incrementing a key's frequency and finding the next eviction
+// candidate are both O(1), as described in "An O(1) algorithm for
+// implementing the LFU cache eviction scheme".
+type lfuPolicy struct {
+	mu          sync.Mutex
+	minFreq     int
+	freqBuckets map[int]*list.List
+	items       map[interface{}]*list.Element
+}
+
+// NewLFUPolicy creates an EvictionPolicy that evicts the least frequently
+// used key, keeping a minimum-frequency pointer so eviction does not
+// require scanning every key.
+func NewLFUPolicy() EvictionPolicy {
+	return &lfuPolicy{
+		freqBuckets: map[int]*list.List{},
+		items:       map[interface{}]*list.Element{},
+	}
+}
+
+func (p *lfuPolicy) bucket(freq int) *list.List {
+	b, exists := p.freqBuckets[freq]
+	if !exists {
+		b = list.New()
+		p.freqBuckets[freq] = b
+	}
+	return b
+}
+
+func (p *lfuPolicy) bump(key interface{}) {
+	elem, exists := p.items[key]
+	if !exists {
+		elem = p.bucket(1).PushFront(&lfuEntry{key: key, freq: 1})
+		p.items[key] = elem
+		p.minFreq = 1
+		return
+	}
+	entry := elem.Value.(*lfuEntry)
+	oldBucket := p.freqBuckets[entry.freq]
+	oldBucket.Remove(elem)
+	if oldBucket.Len() == 0 && p.minFreq == entry.freq {
+		p.minFreq++
+	}
+	entry.freq++
+	p.items[key] = p.bucket(entry.freq).PushFront(entry)
+}
+
+func (p *lfuPolicy) OnGet(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, exists := p.items[key]; exists {
+		p.bump(key)
+	}
+}
+
+func (p *lfuPolicy) OnPut(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bump(key)
+}
+
+func (p *lfuPolicy) OnDelete(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	elem, exists := p.items[key]
+	if !exists {
+		return
+	}
+	entry := elem.Value.(*lfuEntry)
+	p.freqBuckets[entry.freq].Remove(elem)
+	delete(p.items, key)
+}
+
+func (p *lfuPolicy) Evict() (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		bucket, exists := p.freqBuckets[p.minFreq]
+		if !exists || bucket.Len() == 0 {
+			if len(p.items) == 0 {
+				return nil, false
+			}
+			p.minFreq++
+			continue
+		}
+		back := bucket.Back()
+		entry := back.Value.(*lfuEntry)
+		bucket.Remove(back)
+		delete(p.items, entry.key)
+		return entry.key, true
+	}
+}
+
+// twoQPolicy implements the 2Q eviction algorithm described by Johnson and
+// Shasha: new keys enter a recency FIFO (A1in), keys aged out of A1in are
+// remembered in a ghost FIFO (A1out) that holds no values, and a key
+// requested again while in A1out is promoted straight into the main LRU
+// (Am).
+type twoQPolicy struct {
+	mu sync.Mutex
+
+	a1inCap  int
+	a1outCap int
+
+	a1in  *list.List
+	a1out *list.List
+	am    *list.List
+
+	a1inItems  map[interface{}]*list.Element
+	a1outItems map[interface{}]*list.Element
+	amItems    map[interface{}]*list.Element
+}
+
+// New2QPolicy creates an EvictionPolicy implementing the 2Q algorithm.
+// maxSize should match the CacheOptions.MaxSize the policy is paired
+// with, so the recent (A1in) and ghost (A1out) FIFOs are sized
+// proportionally to it: A1in holds ~recentRatio of maxSize entries and
+// A1out tracks ~ghostRatio of maxSize keys. A recentRatio of 0.25 and a
+// ghostRatio of 0.5 match the ratios from the original paper.
+func New2QPolicy(maxSize int, recentRatio, ghostRatio float64) EvictionPolicy {
+	a1inCap := int(float64(maxSize) * recentRatio)
+	if a1inCap < 1 {
+		a1inCap = 1
+	}
+	a1outCap := int(float64(maxSize) * ghostRatio)
+	if a1outCap < 1 {
+		a1outCap = 1
+	}
+	return &twoQPolicy{
+		a1inCap:    a1inCap,
+		a1outCap:   a1outCap,
+		a1in:       list.New(),
+		a1out:      list.New(),
+		am:         list.New(),
+		a1inItems:  map[interface{}]*list.Element{},
+		a1outItems: map[interface{}]*list.Element{},
+		amItems:    map[interface{}]*list.Element{},
+	}
+}
+
+func (p *twoQPolicy) OnGet(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if elem, exists := p.amItems[key]; exists {
+		p.am.MoveToFront(elem)
+	}
+	// A hit on A1in does not change its FIFO order.
+}
+
+func (p *twoQPolicy) OnPut(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if elem, exists := p.amItems[key]; exists {
+		p.am.MoveToFront(elem)
+		return
+	}
+	if elem, exists := p.a1outItems[key]; exists {
+		// Seen recently enough to come back from the ghost list:
+		// treat it as hot and promote it straight into Am.
+		p.a1out.Remove(elem)
+		delete(p.a1outItems, key)
+		p.amItems[key] = p.am.PushFront(key)
+		return
+	}
+	if elem, exists := p.a1inItems[key]; exists {
+		p.a1in.MoveToFront(elem)
+		return
+	}
+	p.a1inItems[key] = p.a1in.PushFront(key)
+}
+
+func (p *twoQPolicy) OnDelete(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if elem, exists := p.a1inItems[key]; exists {
+		p.a1in.Remove(elem)
+		delete(p.a1inItems, key)
+	}
+	if elem, exists := p.a1outItems[key]; exists {
+		p.a1out.Remove(elem)
+		delete(p.a1outItems, key)
+	}
+	if elem, exists := p.amItems[key]; exists {
+		p.am.Remove(elem)
+		delete(p.amItems, key)
+	}
+}
+
+func (p *twoQPolicy) Evict() (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.a1in.Len() > p.a1inCap {
+		back := p.a1in.Back()
+		key := back.Value
+		p.a1in.Remove(back)
+		delete(p.a1inItems, key)
+
+		// Remember it as a ghost so a near-term re-access promotes it
+		// to the main LRU instead of re-entering A1in.
+		if p.a1out.Len() >= p.a1outCap {
+			oldest := p.a1out.Back()
+			if oldest != nil {
+				delete(p.a1outItems, oldest.Value)
+				p.a1out.Remove(oldest)
+			}
+		}
+		p.a1outItems[key] = p.a1out.PushFront(key)
+		return key, true
+	}
+
+	if back := p.am.Back(); back != nil {
+		key := back.Value
+		p.am.Remove(back)
+		delete(p.amItems, key)
+		return key, true
+	}
+
+	// Am is empty, fall back to evicting from A1in even if it is
+	// within its capacity, since there is nowhere else to take from.
+	if back := p.a1in.Back(); back != nil {
+		key := back.Value
+		p.a1in.Remove(back)
+		delete(p.a1inItems, key)
+		return key, true
+	}
+
+	return nil, false
+}
+
+// sieveEntry tracks whether a key has been accessed since it was last
+// visited by the SIEVE hand.
+type sieveEntry struct {
+	key     interface{}
+	visited bool
+}
+
+// sievePolicy implements the SIEVE eviction algorithm: a single FIFO
+// holds every key, new keys are inserted at the head, and a hand
+// pointer walks from the tail clearing visited bits until it finds an
+// unvisited key to evict.
+type sievePolicy struct {
+	mu    sync.Mutex
+	ll    *list.List
+	items map[interface{}]*list.Element
+	hand  *list.Element
+}
+
+// NewSievePolicy creates an EvictionPolicy implementing SIEVE, as
+// described in "SIEVE is Simpler than LRU".
+func NewSievePolicy() EvictionPolicy {
+	return &sievePolicy{
+		ll:    list.New(),
+		items: map[interface{}]*list.Element{},
+	}
+}
+
+func (p *sievePolicy) OnGet(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if elem, exists := p.items[key]; exists {
+		elem.Value.(*sieveEntry).visited = true
+	}
+}
+
+func (p *sievePolicy) OnPut(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if elem, exists := p.items[key]; exists {
+		elem.Value.(*sieveEntry).visited = true
+		return
+	}
+	p.items[key] = p.ll.PushFront(&sieveEntry{key: key})
+}
+
+func (p *sievePolicy) OnDelete(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	elem, exists := p.items[key]
+	if !exists {
+		return
+	}
+	if p.hand == elem {
+		p.hand = p.prevOrWrap(elem)
+	}
+	p.ll.Remove(elem)
+	delete(p.items, key)
+}
+
+func (p *sievePolicy) prevOrWrap(elem *list.Element) *list.Element {
+	if prev := elem.Prev(); prev != nil {
+		return prev
+	}
+	return p.ll.Back()
+}
+
+func (p *sievePolicy) Evict() (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	hand := p.hand
+	if hand == nil {
+		hand = p.ll.Back()
+	}
+	for i := 0; hand != nil && i <= p.ll.Len(); i++ {
+		entry := hand.Value.(*sieveEntry)
+		if !entry.visited {
+			p.hand = p.prevOrWrap(hand)
+			if p.hand == hand {
+				p.hand = nil
+			}
+			p.ll.Remove(hand)
+			delete(p.items, entry.key)
+			return entry.key, true
+		}
+		entry.visited = false
+		hand = p.prevOrWrap(hand)
+	}
+	return nil, false
+}
@@ -0,0 +1,82 @@
+package loadingcache
+
+import "testing"
+
+// evictOne asks the policy for a victim the way genericCache.evict does
+// for a RemovalReasonSize eviction: it does not also call OnDelete for
+// that key, since Evict() is responsible for its own bookkeeping (and,
+// for ghost-list policies like 2Q, may deliberately keep a trace of the
+// evicted key around).
+func evictOne(t *testing.T, p EvictionPolicy) interface{} {
+	t.Helper()
+	key, ok := p.Evict()
+	if !ok {
+		t.Fatalf("Evict: expected a key, got none")
+	}
+	return key
+}
+
+func TestLRUPolicyEvictsLeastRecentlyUsed(t *testing.T) {
+	p := NewLRUPolicy()
+	p.OnPut("a")
+	p.OnPut("b")
+	p.OnPut("c")
+	p.OnGet("a") // "a" is now the most recently used, "b" is the oldest.
+
+	if evicted := evictOne(t, p); evicted != "b" {
+		t.Fatalf("expected to evict %q, got %q", "b", evicted)
+	}
+}
+
+func TestLFUPolicyEvictsLeastFrequentlyUsed(t *testing.T) {
+	p := NewLFUPolicy()
+	p.OnPut("a")
+	p.OnPut("b")
+	p.OnGet("a")
+	p.OnGet("a") // "a" now has a higher frequency than "b".
+
+	if evicted := evictOne(t, p); evicted != "b" {
+		t.Fatalf("expected to evict %q, got %q", "b", evicted)
+	}
+}
+
+func TestSievePolicyEvictsUnvisitedKey(t *testing.T) {
+	p := NewSievePolicy()
+	p.OnPut("a")
+	p.OnPut("b")
+	p.OnGet("a") // visiting "a" spares it from the first sweep.
+
+	if evicted := evictOne(t, p); evicted != "b" {
+		t.Fatalf("expected to evict %q, got %q", "b", evicted)
+	}
+}
+
+// TestTwoQPolicyPromotesGhostHitToMainList exercises the full ghost-list
+// lifecycle: a key aged out of A1in must survive as a ghost (genericCache
+// does not call OnDelete for a key its own Evict() just returned, see
+// evictOne), and a re-access while it is a ghost must promote it into Am
+// rather than being treated as a brand new A1in entry.
+func TestTwoQPolicyPromotesGhostHitToMainList(t *testing.T) {
+	p := New2QPolicy(4, 0.25, 0.5).(*twoQPolicy)
+
+	p.OnPut("a") // a1inCap is 1, so "a" alone fills A1in.
+	p.OnPut("b")
+
+	victim := evictOne(t, p)
+	if victim != "a" {
+		t.Fatalf("expected %q to be evicted into the ghost list, got %q", "a", victim)
+	}
+	if _, isGhost := p.a1outItems[victim]; !isGhost {
+		t.Fatalf("expected %q to remain a ghost, got evicted from A1out entirely", victim)
+	}
+
+	// A put for the ghosted key must promote it into Am, not re-insert
+	// it into A1in.
+	p.OnPut("a")
+	if _, inAm := p.amItems["a"]; !inAm {
+		t.Fatalf("expected %q to be promoted into Am after a ghost hit", "a")
+	}
+	if _, inA1in := p.a1inItems["a"]; inA1in {
+		t.Fatalf("expected %q not to re-enter A1in after a ghost hit", "a")
+	}
+}
@@ -0,0 +1,4 @@
+package example
+
+// Name is the key type CoolCache is keyed on.
+type Name string
@@ -0,0 +1,137 @@
+// Package stats implements the counters backing a cache's Stats().
+package stats
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is the exported, read-only view of a cache's statistics.
+//
+// Add is part of this interface (rather than being restricted to
+// *InternalStats) so callers aggregating stats across several caches,
+// e.g. the shards of a sharded cache, can do so through the interface
+// alone, without knowing about InternalStats or any other concrete
+// implementation.
+type Stats interface {
+	HitCount() int64
+	MissCount() int64
+	LoadSuccessCount() int64
+	LoadErrorCount() int64
+	RefreshSuccessCount() int64
+	RefreshErrorCount() int64
+	EvictionCount() int64
+	TotalLoadTime() time.Duration
+
+	// Add returns a new Stats combining this one with other.
+	Add(other Stats) Stats
+}
+
+// InternalStats is the Stats implementation used internally by every
+// cache to track its own counters. Its methods are safe for concurrent
+// use.
+type InternalStats struct {
+	hitCount            int64
+	missCount           int64
+	loadSuccessCount    int64
+	loadErrorCount      int64
+	refreshSuccessCount int64
+	refreshErrorCount   int64
+	evictionCount       int64
+	totalLoadTime       int64 // nanoseconds
+}
+
+// Hit records a cache hit.
+func (s *InternalStats) Hit() {
+	atomic.AddInt64(&s.hitCount, 1)
+}
+
+// Miss records a cache miss.
+func (s *InternalStats) Miss() {
+	atomic.AddInt64(&s.missCount, 1)
+}
+
+// LoadSuccess records a successful Load call.
+func (s *InternalStats) LoadSuccess() {
+	atomic.AddInt64(&s.loadSuccessCount, 1)
+}
+
+// LoadError records a failed Load call.
+func (s *InternalStats) LoadError() {
+	atomic.AddInt64(&s.loadErrorCount, 1)
+}
+
+// RefreshSuccess records a successful background or explicit refresh.
+func (s *InternalStats) RefreshSuccess() {
+	atomic.AddInt64(&s.refreshSuccessCount, 1)
+}
+
+// RefreshError records a failed background or explicit refresh.
+func (s *InternalStats) RefreshError() {
+	atomic.AddInt64(&s.refreshErrorCount, 1)
+}
+
+// Eviction records an entry being evicted, for any reason.
+func (s *InternalStats) Eviction() {
+	atomic.AddInt64(&s.evictionCount, 1)
+}
+
+// LoadTime accumulates time spent inside Load calls.
+func (s *InternalStats) LoadTime(d time.Duration) {
+	atomic.AddInt64(&s.totalLoadTime, int64(d))
+}
+
+// HitCount implements Stats.
+func (s *InternalStats) HitCount() int64 { return atomic.LoadInt64(&s.hitCount) }
+
+// MissCount implements Stats.
+func (s *InternalStats) MissCount() int64 { return atomic.LoadInt64(&s.missCount) }
+
+// LoadSuccessCount implements Stats.
+func (s *InternalStats) LoadSuccessCount() int64 { return atomic.LoadInt64(&s.loadSuccessCount) }
+
+// LoadErrorCount implements Stats.
+func (s *InternalStats) LoadErrorCount() int64 { return atomic.LoadInt64(&s.loadErrorCount) }
+
+// RefreshSuccessCount implements Stats.
+func (s *InternalStats) RefreshSuccessCount() int64 {
+	return atomic.LoadInt64(&s.refreshSuccessCount)
+}
+
+// RefreshErrorCount implements Stats.
+func (s *InternalStats) RefreshErrorCount() int64 { return atomic.LoadInt64(&s.refreshErrorCount) }
+
+// EvictionCount implements Stats.
+func (s *InternalStats) EvictionCount() int64 { return atomic.LoadInt64(&s.evictionCount) }
+
+// TotalLoadTime implements Stats.
+func (s *InternalStats) TotalLoadTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&s.totalLoadTime))
+}
+
+// Add implements Stats, returning a new *InternalStats with every
+// counter summed.
+func (s *InternalStats) Add(other Stats) Stats {
+	sum := &InternalStats{
+		hitCount:            s.HitCount(),
+		missCount:           s.MissCount(),
+		loadSuccessCount:    s.LoadSuccessCount(),
+		loadErrorCount:      s.LoadErrorCount(),
+		refreshSuccessCount: s.RefreshSuccessCount(),
+		refreshErrorCount:   s.RefreshErrorCount(),
+		evictionCount:       s.EvictionCount(),
+		totalLoadTime:       int64(s.TotalLoadTime()),
+	}
+	if other == nil {
+		return sum
+	}
+	sum.hitCount += other.HitCount()
+	sum.missCount += other.MissCount()
+	sum.loadSuccessCount += other.LoadSuccessCount()
+	sum.loadErrorCount += other.LoadErrorCount()
+	sum.refreshSuccessCount += other.RefreshSuccessCount()
+	sum.refreshErrorCount += other.RefreshErrorCount()
+	sum.evictionCount += other.EvictionCount()
+	sum.totalLoadTime += int64(other.TotalLoadTime())
+	return sum
+}
@@ -0,0 +1,96 @@
+package loadingcache
+
+import (
+	"sync"
+	"time"
+)
+
+// MetricsCollector receives a callback for every cache event worth
+// exporting to a monitoring system: hits, misses, load and refresh
+// successes/failures (with how long Load took), evictions (labeled with
+// their RemovalReason) and the current size. See the metrics/prometheus
+// and metrics/otel subpackages for ready-made implementations.
+type MetricsCollector interface {
+	Hit()
+	Miss()
+	LoadSuccess(duration time.Duration)
+	LoadError(duration time.Duration)
+	RefreshSuccess(duration time.Duration)
+	RefreshError(duration time.Duration)
+	Eviction(reason RemovalReason)
+	Size(count int)
+}
+
+// noopMetricsCollector is the default MetricsCollector: it does
+// nothing, so a cache without one configured pays no overhead beyond
+// the interface call itself.
+type noopMetricsCollector struct{}
+
+func (noopMetricsCollector) Hit()                         {}
+func (noopMetricsCollector) Miss()                        {}
+func (noopMetricsCollector) LoadSuccess(time.Duration)    {}
+func (noopMetricsCollector) LoadError(time.Duration)      {}
+func (noopMetricsCollector) RefreshSuccess(time.Duration) {}
+func (noopMetricsCollector) RefreshError(time.Duration)   {}
+func (noopMetricsCollector) Eviction(RemovalReason)       {}
+func (noopMetricsCollector) Size(int)                     {}
+
+// shardSizeAggregator lets every shard of a sharded cache report its own
+// absolute size while still exporting the true cache-wide total to a
+// single MetricsCollector. Unlike the other callbacks, which are plain
+// increments and sum naturally across shards on their own, Size is a
+// last-value-wins gauge: without this, concurrent shards reporting
+// their own counts would overwrite each other's value instead of adding
+// up to the real total.
+type shardSizeAggregator struct {
+	collector MetricsCollector
+
+	mu    sync.Mutex
+	sizes []int64
+}
+
+func newShardSizeAggregator(collector MetricsCollector, shardCount int) *shardSizeAggregator {
+	return &shardSizeAggregator{collector: collector, sizes: make([]int64, shardCount)}
+}
+
+func (a *shardSizeAggregator) report(shard int, size int) {
+	a.mu.Lock()
+	a.sizes[shard] = int64(size)
+	var total int64
+	for _, s := range a.sizes {
+		total += s
+	}
+	a.mu.Unlock()
+	a.collector.Size(int(total))
+}
+
+// forShard returns a MetricsCollector for one shard: every callback
+// other than Size is forwarded straight to the shared collector, while
+// Size reports the sum across every shard instead of this shard alone.
+func (a *shardSizeAggregator) forShard(shard int) MetricsCollector {
+	return &shardMetricsCollector{aggregator: a, shard: shard}
+}
+
+type shardMetricsCollector struct {
+	aggregator *shardSizeAggregator
+	shard      int
+}
+
+func (s *shardMetricsCollector) Hit()  { s.aggregator.collector.Hit() }
+func (s *shardMetricsCollector) Miss() { s.aggregator.collector.Miss() }
+func (s *shardMetricsCollector) LoadSuccess(d time.Duration) {
+	s.aggregator.collector.LoadSuccess(d)
+}
+func (s *shardMetricsCollector) LoadError(d time.Duration) {
+	s.aggregator.collector.LoadError(d)
+}
+func (s *shardMetricsCollector) RefreshSuccess(d time.Duration) {
+	s.aggregator.collector.RefreshSuccess(d)
+}
+func (s *shardMetricsCollector) RefreshError(d time.Duration) {
+	s.aggregator.collector.RefreshError(d)
+}
+func (s *shardMetricsCollector) Eviction(reason RemovalReason) {
+	s.aggregator.collector.Eviction(reason)
+}
+func (s *shardMetricsCollector) Size(count int) { s.aggregator.report(s.shard, count) }
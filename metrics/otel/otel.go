@@ -0,0 +1,126 @@
+// Package otel provides a loadingcache.MetricsCollector that exports
+// cache activity through an OpenTelemetry metric.Meter.
+package otel
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/Hartimer/loadingcache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Collector implements loadingcache.MetricsCollector on top of an
+// OpenTelemetry Meter, attaching a "cache" attribute set to name to
+// every recorded measurement.
+type Collector struct {
+	attrs     attribute.Set
+	hits      metric.Int64Counter
+	misses    metric.Int64Counter
+	loads     metric.Int64Counter
+	refreshes metric.Int64Counter
+	loadTime  metric.Float64Histogram
+	evictions metric.Int64Counter
+	size      metric.Int64UpDownCounter
+	lastSize  int64
+}
+
+// New creates a Collector backed by meter, labeling every metric with
+// name.
+func New(meter metric.Meter, name string) (*Collector, error) {
+	hits, err := meter.Int64Counter("loadingcache.hits")
+	if err != nil {
+		return nil, err
+	}
+	misses, err := meter.Int64Counter("loadingcache.misses")
+	if err != nil {
+		return nil, err
+	}
+	loads, err := meter.Int64Counter("loadingcache.loads")
+	if err != nil {
+		return nil, err
+	}
+	refreshes, err := meter.Int64Counter("loadingcache.refreshes")
+	if err != nil {
+		return nil, err
+	}
+	loadTime, err := meter.Float64Histogram("loadingcache.load_duration_seconds")
+	if err != nil {
+		return nil, err
+	}
+	evictions, err := meter.Int64Counter("loadingcache.evictions")
+	if err != nil {
+		return nil, err
+	}
+	size, err := meter.Int64UpDownCounter("loadingcache.size")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Collector{
+		attrs:     attribute.NewSet(attribute.String("cache", name)),
+		hits:      hits,
+		misses:    misses,
+		loads:     loads,
+		refreshes: refreshes,
+		loadTime:  loadTime,
+		evictions: evictions,
+		size:      size,
+	}, nil
+}
+
+func (c *Collector) opt() metric.MeasurementOption {
+	return metric.WithAttributeSet(c.attrs)
+}
+
+// withAttr returns a MeasurementOption carrying both the cache's own
+// "cache" attribute and one extra key/value, so per-result or
+// per-reason breakdowns stay attributed to the cache they came from.
+func (c *Collector) withAttr(key, value string) metric.MeasurementOption {
+	kvs := append(c.attrs.ToSlice(), attribute.String(key, value))
+	return metric.WithAttributeSet(attribute.NewSet(kvs...))
+}
+
+func (c *Collector) Hit() { c.hits.Add(context.Background(), 1, c.opt()) }
+
+func (c *Collector) Miss() { c.misses.Add(context.Background(), 1, c.opt()) }
+
+func (c *Collector) LoadSuccess(duration time.Duration) {
+	ctx := context.Background()
+	c.loads.Add(ctx, 1, c.withAttr("result", "success"))
+	c.loadTime.Record(ctx, duration.Seconds(), c.opt())
+}
+
+func (c *Collector) LoadError(duration time.Duration) {
+	ctx := context.Background()
+	c.loads.Add(ctx, 1, c.withAttr("result", "error"))
+	c.loadTime.Record(ctx, duration.Seconds(), c.opt())
+}
+
+func (c *Collector) RefreshSuccess(duration time.Duration) {
+	ctx := context.Background()
+	c.refreshes.Add(ctx, 1, c.withAttr("result", "success"))
+	c.loadTime.Record(ctx, duration.Seconds(), c.opt())
+}
+
+func (c *Collector) RefreshError(duration time.Duration) {
+	ctx := context.Background()
+	c.refreshes.Add(ctx, 1, c.withAttr("result", "error"))
+	c.loadTime.Record(ctx, duration.Seconds(), c.opt())
+}
+
+func (c *Collector) Eviction(reason loadingcache.RemovalReason) {
+	c.evictions.Add(context.Background(), 1, c.withAttr("reason", string(reason)))
+}
+
+// Size reports the current cache size. Int64UpDownCounter only exposes
+// Add, so this records the delta from the last reported size rather
+// than the absolute count.
+func (c *Collector) Size(count int) {
+	previous := atomic.SwapInt64(&c.lastSize, int64(count))
+	c.size.Add(context.Background(), int64(count)-previous, c.opt())
+}
+
+var _ loadingcache.MetricsCollector = (*Collector)(nil)
@@ -0,0 +1,103 @@
+// Package prometheus provides a loadingcache.MetricsCollector that
+// exports cache activity as Prometheus metrics.
+package prometheus
+
+import (
+	"time"
+
+	"github.com/Hartimer/loadingcache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector implements loadingcache.MetricsCollector, labeling every
+// metric with the cache Name it was constructed with so several caches
+// can share one registry.
+type Collector struct {
+	hits     prometheus.Counter
+	misses   prometheus.Counter
+	loads    *prometheus.CounterVec
+	refresh  *prometheus.CounterVec
+	loadTime prometheus.Histogram
+	evicts   *prometheus.CounterVec
+	size     prometheus.Gauge
+}
+
+// New creates a Collector and registers its metrics on registerer,
+// labeled with name.
+func New(registerer prometheus.Registerer, name string) *Collector {
+	constLabels := prometheus.Labels{"cache": name}
+
+	c := &Collector{
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "loadingcache_hits_total",
+			Help:        "Number of cache hits.",
+			ConstLabels: constLabels,
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "loadingcache_misses_total",
+			Help:        "Number of cache misses.",
+			ConstLabels: constLabels,
+		}),
+		loads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "loadingcache_loads_total",
+			Help:        "Number of Load calls, by result.",
+			ConstLabels: constLabels,
+		}, []string{"result"}),
+		refresh: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "loadingcache_refreshes_total",
+			Help:        "Number of background/explicit refreshes, by result.",
+			ConstLabels: constLabels,
+		}, []string{"result"}),
+		loadTime: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:        "loadingcache_load_duration_seconds",
+			Help:        "Time spent inside Load calls.",
+			ConstLabels: constLabels,
+		}),
+		evicts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "loadingcache_evictions_total",
+			Help:        "Number of evictions, by reason.",
+			ConstLabels: constLabels,
+		}, []string{"reason"}),
+		size: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "loadingcache_size",
+			Help:        "Current number of entries in the cache.",
+			ConstLabels: constLabels,
+		}),
+	}
+
+	registerer.MustRegister(c.hits, c.misses, c.loads, c.refresh, c.loadTime, c.evicts, c.size)
+	return c
+}
+
+func (c *Collector) Hit()  { c.hits.Inc() }
+func (c *Collector) Miss() { c.misses.Inc() }
+
+func (c *Collector) LoadSuccess(duration time.Duration) {
+	c.loads.WithLabelValues("success").Inc()
+	c.loadTime.Observe(duration.Seconds())
+}
+
+func (c *Collector) LoadError(duration time.Duration) {
+	c.loads.WithLabelValues("error").Inc()
+	c.loadTime.Observe(duration.Seconds())
+}
+
+func (c *Collector) RefreshSuccess(duration time.Duration) {
+	c.refresh.WithLabelValues("success").Inc()
+	c.loadTime.Observe(duration.Seconds())
+}
+
+func (c *Collector) RefreshError(duration time.Duration) {
+	c.refresh.WithLabelValues("error").Inc()
+	c.loadTime.Observe(duration.Seconds())
+}
+
+func (c *Collector) Eviction(reason loadingcache.RemovalReason) {
+	c.evicts.WithLabelValues(string(reason)).Inc()
+}
+
+func (c *Collector) Size(count int) {
+	c.size.Set(float64(count))
+}
+
+var _ loadingcache.MetricsCollector = (*Collector)(nil)
@@ -0,0 +1,32 @@
+package loadingcache
+
+import (
+	"testing"
+	"time"
+)
+
+type recordingMetricsCollector struct {
+	sizes []int
+}
+
+func (r *recordingMetricsCollector) Hit()                         {}
+func (r *recordingMetricsCollector) Miss()                        {}
+func (r *recordingMetricsCollector) LoadSuccess(time.Duration)    {}
+func (r *recordingMetricsCollector) LoadError(time.Duration)      {}
+func (r *recordingMetricsCollector) RefreshSuccess(time.Duration) {}
+func (r *recordingMetricsCollector) RefreshError(time.Duration)   {}
+func (r *recordingMetricsCollector) Eviction(RemovalReason)       {}
+func (r *recordingMetricsCollector) Size(count int)               { r.sizes = append(r.sizes, count) }
+
+func TestShardSizeAggregatorSumsAcrossShards(t *testing.T) {
+	recorder := &recordingMetricsCollector{}
+	aggregator := newShardSizeAggregator(recorder, 2)
+
+	aggregator.forShard(0).Size(3)
+	aggregator.forShard(1).Size(3)
+
+	last := recorder.sizes[len(recorder.sizes)-1]
+	if last != 6 {
+		t.Fatalf("expected the aggregated size to be 6, got %d", last)
+	}
+}
@@ -0,0 +1,52 @@
+package loadingcache
+
+import "sync"
+
+// call represents an in-flight or completed invocation of a
+// singleflightGroup function.
+type call struct {
+	wg  sync.WaitGroup
+	val interface{}
+	err error
+}
+
+// singleflightGroup coalesces concurrent calls for the same key into a
+// single execution of fn, so that e.g. N concurrent misses on the same
+// key only ever result in one LoadFunc invocation. This mirrors
+// golang.org/x/sync/singleflight.Group, trimmed down to what the cache
+// needs.
+type singleflightGroup struct {
+	mu    sync.Mutex
+	calls map[interface{}]*call
+}
+
+// Do executes fn, making sure that only one execution is in-flight for a
+// given key at a time. Concurrent callers wait for the original call to
+// complete and receive the same results. The shared return value
+// reports whether the caller got the result from a call it did not
+// itself trigger.
+func (g *singleflightGroup) Do(key interface{}, fn func() (interface{}, error)) (val interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[interface{}]*call{}
+	}
+	if c, exists := g.calls[key]; exists {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+
+	c := new(call)
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.val, c.err = fn()
+	c.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return c.val, c.err, false
+}
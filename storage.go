@@ -0,0 +1,66 @@
+package loadingcache
+
+// Storage abstracts how a genericCache keeps its entries. The default,
+// used when CacheOptions.NewStorage is not set, is a plain
+// map[interface{}]*cacheEntry. BytesStorage is an alternative,
+// bigcache-inspired backend that keeps entries serialized in a byte
+// arena instead of as individual heap objects, trading a small
+// (de)serialization cost for dramatically less garbage for the Go
+// collector to scan on multi-million-entry caches.
+//
+// genericCache only ever calls into Storage while holding its dataLock:
+// Put, Delete, ForEach and Len under the write lock, and Get under
+// either lock, so several Get calls can run concurrently with each other
+// but never with any other method.
+type Storage interface {
+	// Get returns the entry associated with key, if any.
+	Get(key interface{}) (*cacheEntry, bool)
+
+	// Put stores entry under key, replacing any previous entry.
+	Put(key interface{}, entry *cacheEntry)
+
+	// Delete removes key, if present. It is a noop otherwise.
+	Delete(key interface{})
+
+	// ForEach calls fn for every stored entry. Iteration stops early if
+	// fn returns false.
+	ForEach(fn func(entry *cacheEntry) bool)
+
+	// Len returns the number of entries currently stored.
+	Len() int
+}
+
+// mapStorage is the default Storage implementation, a thin wrapper
+// around a map[interface{}]*cacheEntry.
+type mapStorage struct {
+	data map[interface{}]*cacheEntry
+}
+
+func newMapStorage() Storage {
+	return &mapStorage{data: map[interface{}]*cacheEntry{}}
+}
+
+func (m *mapStorage) Get(key interface{}) (*cacheEntry, bool) {
+	entry, exists := m.data[key]
+	return entry, exists
+}
+
+func (m *mapStorage) Put(key interface{}, entry *cacheEntry) {
+	m.data[key] = entry
+}
+
+func (m *mapStorage) Delete(key interface{}) {
+	delete(m.data, key)
+}
+
+func (m *mapStorage) ForEach(fn func(entry *cacheEntry) bool) {
+	for _, entry := range m.data {
+		if !fn(entry) {
+			return
+		}
+	}
+}
+
+func (m *mapStorage) Len() int {
+	return len(m.data)
+}
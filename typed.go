@@ -0,0 +1,183 @@
+package loadingcache
+
+import (
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/pkg/errors"
+)
+
+// TypedLoadFunc represents a function that given a key, it returns a
+// value or an error. It is the generic counterpart of LoadFunc.
+type TypedLoadFunc[K comparable, V any] func(K) (V, error)
+
+// TypedRemovalNotification is passed to a TypedRemovalListener everytime
+// an entry is removed. It is the generic counterpart of
+// RemovalNotification.
+type TypedRemovalNotification[K comparable, V any] struct {
+	Key    K
+	Value  V
+	Reason RemovalReason
+}
+
+// TypedRemovalListener represents a removal listener for a TypedCache.
+type TypedRemovalListener[K comparable, V any] func(TypedRemovalNotification[K, V])
+
+// TypedCacheOptions mirrors CacheOptions, but with every key/value
+// touching function expressed in terms of K and V instead of
+// interface{}.
+type TypedCacheOptions[K comparable, V any] struct {
+	Clock                    clock.Clock
+	ExpireAfterWrite         time.Duration
+	ExpireAfterRead          time.Duration
+	Load                     TypedLoadFunc[K, V]
+	MaxSize                  int32
+	RemovalListeners         []TypedRemovalListener[K, V]
+	ShardCount               int
+	HashCodeFunc             func(key K) int
+	BackgroundEvictFrequency time.Duration
+	NewEvictionPolicy        func() EvictionPolicy
+	NewStorage               func() Storage
+	NegativeCacheTTL         time.Duration
+	NegativeCacheErrors      func(error) bool
+	RefreshAfterWrite        time.Duration
+	RefreshErrorHandler      func(key K, err error)
+	EventBus                 EventBus
+	MetricsCollector         MetricsCollector
+}
+
+// TypedCache is a generic, strongly typed wrapper around Cache, so keys
+// and values are compile-time checked rather than boxed into
+// interface{} by hand at every call site. Internally it still delegates
+// to the interface{}-based Cache, unboxing values with a checked type
+// assertion: Get returns an error instead of a zero value if a stored
+// value is not a V.
+type TypedCache[K comparable, V any] struct {
+	untyped    Cache
+	shardCount int
+}
+
+// NewTyped instantiates a new TypedCache. It supersedes
+// cmd/typedcache: since Go 1.18, a dedicated Cache[Name, int64] no
+// longer needs to be code-generated, it can be instantiated directly as
+// NewTyped[Name, int64](...).
+func NewTyped[K comparable, V any](options TypedCacheOptions[K, V]) *TypedCache[K, V] {
+	shardCount := options.ShardCount
+	if shardCount <= 0 {
+		shardCount = 1
+	}
+
+	untypedOptions := CacheOptions{
+		Clock:                    options.Clock,
+		ExpireAfterWrite:         options.ExpireAfterWrite,
+		ExpireAfterRead:          options.ExpireAfterRead,
+		MaxSize:                  options.MaxSize,
+		ShardCount:               options.ShardCount,
+		BackgroundEvictFrequency: options.BackgroundEvictFrequency,
+		NewEvictionPolicy:        options.NewEvictionPolicy,
+		NewStorage:               options.NewStorage,
+		NegativeCacheTTL:         options.NegativeCacheTTL,
+		NegativeCacheErrors:      options.NegativeCacheErrors,
+		RefreshAfterWrite:        options.RefreshAfterWrite,
+		EventBus:                 options.EventBus,
+		MetricsCollector:         options.MetricsCollector,
+	}
+
+	if options.Load != nil {
+		load := options.Load
+		untypedOptions.Load = func(key interface{}) (interface{}, error) {
+			return load(key.(K))
+		}
+	}
+	if options.HashCodeFunc != nil {
+		hashCodeFunc := options.HashCodeFunc
+		untypedOptions.HashCodeFunc = func(key interface{}) int {
+			return hashCodeFunc(key.(K))
+		}
+	}
+	if options.RefreshErrorHandler != nil {
+		refreshErrorHandler := options.RefreshErrorHandler
+		untypedOptions.RefreshErrorHandler = func(key interface{}, err error) {
+			refreshErrorHandler(key.(K), err)
+		}
+	}
+	for _, listener := range options.RemovalListeners {
+		typedListener := listener
+		untypedOptions.RemovalListeners = append(untypedOptions.RemovalListeners, func(n RemovalNotification) {
+			// Value is the zero value of V for a removal notification
+			// triggered by a negative cache entry, which never held a V.
+			value, _ := n.Value.(V)
+			typedListener(TypedRemovalNotification[K, V]{
+				Key:    n.Key.(K),
+				Value:  value,
+				Reason: n.Reason,
+			})
+		})
+	}
+
+	return &TypedCache[K, V]{
+		untyped:    New(untypedOptions),
+		shardCount: shardCount,
+	}
+}
+
+// Get returns the value associated with a given key. If no entry exists
+// for the provided key, loadingcache.ErrKeyNotFound is returned.
+func (c *TypedCache[K, V]) Get(key K) (V, error) {
+	var zero V
+	val, err := c.untyped.Get(key)
+	if err != nil {
+		return zero, err
+	}
+	typedVal, ok := val.(V)
+	if !ok {
+		// Every value ever stored for this cache went through
+		// Put(key K, value V), so this can only happen if the same
+		// untyped Cache is shared by more than one TypedCache, which is
+		// not a supported usage.
+		return zero, errors.Errorf("loadingcache: value for key %v is a %T, not a %T", key, val, zero)
+	}
+	return typedVal, nil
+}
+
+// Put adds a value to the cache identified by a key. If a value already
+// exists associated with that key, it is replaced.
+func (c *TypedCache[K, V]) Put(key K, value V) {
+	c.untyped.Put(key, value)
+}
+
+// Invalidate removes keys from the cache. If a key does not exist it is
+// a noop.
+func (c *TypedCache[K, V]) Invalidate(key K, keys ...K) {
+	genericKeys := make([]interface{}, len(keys))
+	for i, k := range keys {
+		genericKeys[i] = k
+	}
+	c.untyped.Invalidate(key, genericKeys...)
+}
+
+// InvalidateAll invalidates all keys.
+func (c *TypedCache[K, V]) InvalidateAll() {
+	c.untyped.InvalidateAll()
+}
+
+// Refresh triggers an asynchronous reload of key through Load. See
+// Cache.Refresh.
+func (c *TypedCache[K, V]) Refresh(key K) {
+	c.untyped.Refresh(key)
+}
+
+// Close cleans up any resources used by the cache.
+func (c *TypedCache[K, V]) Close() {
+	c.untyped.Close()
+}
+
+// Stats returns the current stats.
+func (c *TypedCache[K, V]) Stats() Stats {
+	return c.untyped.Stats()
+}
+
+// ShardCount returns how many shards this cache was configured with.
+func (c *TypedCache[K, V]) ShardCount() int {
+	return c.shardCount
+}
@@ -0,0 +1,77 @@
+package loadingcache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestTypedCacheLoadsGetsAndInvalidates drives NewTyped the way a real
+// caller (e.g. the generated example package) would: concrete K/V types,
+// a Load that boxes/unboxes through the untyped Cache, a Put that
+// replaces the loaded value, and an Invalidate that forces a second Load.
+func TestTypedCacheLoadsGetsAndInvalidates(t *testing.T) {
+	var loads int32
+	cache := NewTyped(TypedCacheOptions[string, int64]{
+		Load: func(key string) (int64, error) {
+			atomic.AddInt32(&loads, 1)
+			return int64(len(key)), nil
+		},
+	})
+	defer cache.Close()
+
+	val, err := cache.Get("hello")
+	if err != nil {
+		t.Fatalf("Get: unexpected error %v", err)
+	}
+	if val != 5 {
+		t.Fatalf("Get: expected 5, got %d", val)
+	}
+
+	cache.Put("hello", 42)
+	val, err = cache.Get("hello")
+	if err != nil {
+		t.Fatalf("Get after Put: unexpected error %v", err)
+	}
+	if val != 42 {
+		t.Fatalf("Get after Put: expected 42, got %d", val)
+	}
+
+	cache.Invalidate("hello")
+	if _, err := cache.Get("hello"); err != nil {
+		t.Fatalf("Get after Invalidate: unexpected error %v", err)
+	}
+	if got := atomic.LoadInt32(&loads); got != 2 {
+		t.Fatalf("expected Load to run twice (initial miss and post-invalidate miss), ran %d times", got)
+	}
+}
+
+// TestTypedCacheRefreshReloadsInBackground exercises Refresh end to end:
+// it must trigger Load again and the new value must be observable
+// through a subsequent Get, confirming TypedCache actually wires Refresh
+// through to the underlying Cache instead of just compiling.
+func TestTypedCacheRefreshReloadsInBackground(t *testing.T) {
+	var value int64 = 1
+	cache := NewTyped(TypedCacheOptions[string, int64]{
+		Load: func(key string) (int64, error) {
+			return atomic.LoadInt64(&value), nil
+		},
+	})
+	defer cache.Close()
+
+	if _, err := cache.Get("k"); err != nil {
+		t.Fatalf("Get: unexpected error %v", err)
+	}
+
+	atomic.StoreInt64(&value, 2)
+	cache.Refresh("k")
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if got, err := cache.Get("k"); err == nil && got == 2 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Refresh: expected Get to observe the refreshed value 2 within 1s")
+}